@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/oauth2-proxy/oauth2-proxy/v7/pkg/apis/options"
+)
+
+// alphaConfigMergeOverride is the value of --alpha-config-merge that
+// silences scalar conflict detection, letting the last file given win.
+const alphaConfigMergeOverride = "override"
+
+// loadMergedAlphaOptions expands each of alphaConfigPaths (a file or a
+// directory of *.yaml/*.yml files) in lexical order and deep-merges them
+// into a single AlphaOptions, ID-keyed slices (providers, upstreams,
+// injectResponseHeaders, injectRequestHeaders) being concatenated and
+// de-duplicated by ID. A scalar set to different values in two files is an
+// error unless mergeStrategy is "override", in which case the later file wins.
+func loadMergedAlphaOptions(alphaConfigPaths []string, mergeStrategy string) (*options.AlphaOptions, error) {
+	files, err := expandAlphaConfigPaths(alphaConfigPaths)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no alpha config files found in %v", alphaConfigPaths)
+	}
+
+	merged := &options.AlphaOptions{}
+	for i, file := range files {
+		current := &options.AlphaOptions{}
+		if err := options.LoadYAML(file, current); err != nil {
+			return nil, fmt.Errorf("failed to load alpha config %q: %v", file, err)
+		}
+		if i == 0 {
+			merged = current
+			continue
+		}
+		if err := mergeAlphaOptions(merged, current, mergeStrategy == alphaConfigMergeOverride, file); err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
+}
+
+// alphaLoggingConfig picks the optional top-level `logging:` block out of an
+// alpha config file. It deliberately doesn't decode into AlphaOptions:
+// options.Options/AlphaOptions don't have a Logging field defined upstream
+// yet, so the proxy's own logging setup is threaded through independently of
+// AlphaOptions.MergeInto/ExtractFrom until that field lands.
+type alphaLoggingConfig struct {
+	Logging *options.LoggingConfiguration `json:"logging,omitempty"`
+}
+
+// loadAlphaLoggingConfiguration merges the `logging:` block (if any) across
+// every expanded --alpha-config file/directory, using the same last-file-wins
+// (override) or fail-on-conflict (strict) semantics as loadMergedAlphaOptions.
+// It returns a nil *options.LoggingConfiguration if none of the files set
+// the block.
+func loadAlphaLoggingConfiguration(alphaConfigPaths []string, mergeStrategy string) (*options.LoggingConfiguration, error) {
+	files, err := expandAlphaConfigPaths(alphaConfigPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	var merged *options.LoggingConfiguration
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read alpha config %q: %v", file, err)
+		}
+
+		var current alphaLoggingConfig
+		if err := yaml.Unmarshal(data, &current); err != nil {
+			return nil, fmt.Errorf("failed to parse alpha config %q: %v", file, err)
+		}
+		if current.Logging == nil {
+			continue
+		}
+		if merged == nil {
+			merged = current.Logging
+			continue
+		}
+		if err := mergeStructs(reflect.ValueOf(merged).Elem(), reflect.ValueOf(current.Logging).Elem(), mergeStrategy == alphaConfigMergeOverride, file, "logging"); err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
+}
+
+// expandAlphaConfigPaths resolves each entry in paths to a sorted list of
+// concrete *.yaml/*.yml files: a plain file is kept as-is, a directory is
+// expanded to every *.yaml/*.yml file it directly contains, in lexical order.
+func expandAlphaConfigPaths(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read alpha config path %q: %v", path, err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		matches, err := filepath.Glob(filepath.Join(path, "*.yaml"))
+		if err != nil {
+			return nil, fmt.Errorf("unable to glob alpha config directory %q: %v", path, err)
+		}
+		ymlMatches, err := filepath.Glob(filepath.Join(path, "*.yml"))
+		if err != nil {
+			return nil, fmt.Errorf("unable to glob alpha config directory %q: %v", path, err)
+		}
+		matches = append(matches, ymlMatches...)
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// mergeAlphaOptions deep-merges src into dst in place. Scalars set on both
+// sides with different values are a conflict unless override is true, in
+// which case src wins. ID-keyed slice fields are concatenated and
+// de-duplicated by ID, last write wins per ID.
+func mergeAlphaOptions(dst, src *options.AlphaOptions, override bool, srcFile string) error {
+	return mergeStructs(reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem(), override, srcFile, "")
+}
+
+func mergeStructs(dst, src reflect.Value, override bool, srcFile, path string) error {
+	for i := 0; i < dst.NumField(); i++ {
+		field := dst.Type().Field(i)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+		fieldPath := field.Name
+		if path != "" {
+			fieldPath = path + "." + field.Name
+		}
+
+		dstField := dst.Field(i)
+		srcField := src.Field(i)
+
+		switch dstField.Kind() {
+		case reflect.Struct:
+			if err := mergeStructs(dstField, srcField, override, srcFile, fieldPath); err != nil {
+				return err
+			}
+		case reflect.Ptr:
+			if srcField.IsNil() {
+				continue
+			}
+			if dstField.IsNil() {
+				dstField.Set(srcField)
+				continue
+			}
+			if dstField.Elem().Kind() == reflect.Struct {
+				if err := mergeStructs(dstField.Elem(), srcField.Elem(), override, srcFile, fieldPath); err != nil {
+					return err
+				}
+				continue
+			}
+			if !reflect.DeepEqual(dstField.Interface(), srcField.Interface()) && !override {
+				return fmt.Errorf("conflicting value for %q in %s: use --alpha-config-merge=override to let later files take precedence", fieldPath, srcFile)
+			}
+			dstField.Set(srcField)
+		case reflect.Slice:
+			if srcField.Len() == 0 {
+				continue
+			}
+			if dstField.Len() == 0 {
+				dstField.Set(srcField)
+				continue
+			}
+			dstField.Set(mergeIDKeyedSlices(dstField, srcField))
+		default:
+			if isZero(srcField) {
+				continue
+			}
+			if isZero(dstField) {
+				dstField.Set(srcField)
+				continue
+			}
+			if !reflect.DeepEqual(dstField.Interface(), srcField.Interface()) && !override {
+				return fmt.Errorf("conflicting value for %q in %s: use --alpha-config-merge=override to let later files take precedence", fieldPath, srcFile)
+			}
+			dstField.Set(srcField)
+		}
+	}
+	return nil
+}
+
+// mergeIDKeyedSlices concatenates dst and src, de-duplicating by the
+// element's ID/Name field (whichever is present) and letting src's entry
+// win on a collision. Elements without an ID/Name field are concatenated
+// as-is, matching how providers/upstreams/header injectors are keyed.
+func mergeIDKeyedSlices(dst, src reflect.Value) reflect.Value {
+	keyed := map[string]int{}
+	result := reflect.MakeSlice(dst.Type(), 0, dst.Len()+src.Len())
+
+	appendOrReplace := func(elem reflect.Value) {
+		key, ok := elementKey(elem)
+		if !ok {
+			result = reflect.Append(result, elem)
+			return
+		}
+		if idx, exists := keyed[key]; exists {
+			result.Index(idx).Set(elem)
+			return
+		}
+		keyed[key] = result.Len()
+		result = reflect.Append(result, elem)
+	}
+
+	for i := 0; i < dst.Len(); i++ {
+		appendOrReplace(dst.Index(i))
+	}
+	for i := 0; i < src.Len(); i++ {
+		appendOrReplace(src.Index(i))
+	}
+	return result
+}
+
+// elementKey returns the value of a slice element's ID or Name field, used
+// to de-duplicate providers, upstreams and header injectors across files.
+func elementKey(elem reflect.Value) (string, bool) {
+	if elem.Kind() != reflect.Struct {
+		return "", false
+	}
+	for _, name := range []string{"ID", "Name"} {
+		field := elem.FieldByName(name)
+		if field.IsValid() && field.Kind() == reflect.String && field.String() != "" {
+			return strings.ToLower(name) + ":" + field.String(), true
+		}
+	}
+	return "", false
+}
+
+func isZero(v reflect.Value) bool {
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
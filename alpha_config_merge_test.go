@@ -0,0 +1,131 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Local stand-ins for AlphaOptions-shaped structs: mergeStructs/
+// mergeIDKeyedSlices/elementKey operate purely via reflection, so they can
+// be exercised without pkg/apis/options.AlphaOptions itself.
+
+type testProvider struct {
+	ID       string
+	ClientID string
+}
+
+type testConfig struct {
+	EmailDomain string
+	Providers   []testProvider
+}
+
+func TestExpandAlphaConfigPathsFileAndDirectory(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.yaml", "a.yml", "not-yaml.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0o600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+
+	singleFile := filepath.Join(t.TempDir(), "single.yaml")
+	if err := os.WriteFile(singleFile, []byte("{}"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	files, err := expandAlphaConfigPaths([]string{singleFile, dir})
+	if err != nil {
+		t.Fatalf("expandAlphaConfigPaths: %v", err)
+	}
+
+	want := []string{singleFile, filepath.Join(dir, "a.yml"), filepath.Join(dir, "b.yaml")}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("expandAlphaConfigPaths = %v, want %v", files, want)
+	}
+}
+
+func TestExpandAlphaConfigPathsMissingPath(t *testing.T) {
+	if _, err := expandAlphaConfigPaths([]string{filepath.Join(t.TempDir(), "missing.yaml")}); err == nil {
+		t.Error("expected an error for a missing path")
+	}
+}
+
+func TestMergeStructsScalarOverride(t *testing.T) {
+	dst := &testConfig{EmailDomain: "a.example.com"}
+	src := &testConfig{EmailDomain: "b.example.com"}
+
+	if err := mergeStructs(reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem(), true, "b.yaml", ""); err != nil {
+		t.Fatalf("mergeStructs: %v", err)
+	}
+	if dst.EmailDomain != "b.example.com" {
+		t.Errorf("EmailDomain = %q, want %q", dst.EmailDomain, "b.example.com")
+	}
+}
+
+func TestMergeStructsScalarConflictWithoutOverride(t *testing.T) {
+	dst := &testConfig{EmailDomain: "a.example.com"}
+	src := &testConfig{EmailDomain: "b.example.com"}
+
+	err := mergeStructs(reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem(), false, "b.yaml", "")
+	if err == nil {
+		t.Fatal("expected a conflict error")
+	}
+}
+
+func TestMergeStructsScalarNoConflictWhenDstUnset(t *testing.T) {
+	dst := &testConfig{}
+	src := &testConfig{EmailDomain: "b.example.com"}
+
+	if err := mergeStructs(reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem(), false, "b.yaml", ""); err != nil {
+		t.Fatalf("mergeStructs: %v", err)
+	}
+	if dst.EmailDomain != "b.example.com" {
+		t.Errorf("EmailDomain = %q, want %q", dst.EmailDomain, "b.example.com")
+	}
+}
+
+func TestMergeIDKeyedSlicesDedupesByID(t *testing.T) {
+	dst := reflect.ValueOf([]testProvider{{ID: "okta", ClientID: "old"}})
+	src := reflect.ValueOf([]testProvider{{ID: "okta", ClientID: "new"}, {ID: "google", ClientID: "g"}})
+
+	merged := mergeIDKeyedSlices(dst, src).Interface().([]testProvider)
+	if len(merged) != 2 {
+		t.Fatalf("got %d providers, want 2: %v", len(merged), merged)
+	}
+	if merged[0].ClientID != "new" {
+		t.Errorf("okta provider ClientID = %q, want %q (later file should win)", merged[0].ClientID, "new")
+	}
+	if merged[1].ID != "google" {
+		t.Errorf("expected google provider to be appended, got %v", merged[1])
+	}
+}
+
+func TestMergeStructsConcatenatesAndDedupesSliceFields(t *testing.T) {
+	dst := &testConfig{Providers: []testProvider{{ID: "okta", ClientID: "old"}}}
+	src := &testConfig{Providers: []testProvider{{ID: "okta", ClientID: "new"}}}
+
+	if err := mergeStructs(reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem(), false, "b.yaml", ""); err != nil {
+		t.Fatalf("mergeStructs: %v", err)
+	}
+	if len(dst.Providers) != 1 || dst.Providers[0].ClientID != "new" {
+		t.Errorf("Providers = %v, want a single okta provider with ClientID %q", dst.Providers, "new")
+	}
+}
+
+func TestElementKeyUsesIDThenName(t *testing.T) {
+	key, ok := elementKey(reflect.ValueOf(testProvider{ID: "okta"}))
+	if !ok || key != "id:okta" {
+		t.Errorf("elementKey = (%q, %v), want (%q, true)", key, ok, "id:okta")
+	}
+
+	type named struct{ Name string }
+	key, ok = elementKey(reflect.ValueOf(named{Name: "conf-d"}))
+	if !ok || key != "name:conf-d" {
+		t.Errorf("elementKey = (%q, %v), want (%q, true)", key, ok, "name:conf-d")
+	}
+
+	if _, ok := elementKey(reflect.ValueOf(42)); ok {
+		t.Error("expected elementKey to reject a non-struct value")
+	}
+}
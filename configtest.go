@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/oauth2-proxy/oauth2-proxy/v7/pkg/apis/options"
+)
+
+// oidcDiscoveryTimeout bounds how long `--check-config=online` waits for an
+// issuer's discovery document before reporting it unreachable.
+const oidcDiscoveryTimeout = 10 * time.Second
+
+// configCheckFailure describes a single configured field that failed a
+// check during `--check-config`. JSONPath follows the same dotted
+// notation used elsewhere in the alpha config docs (e.g. "providers[0].clientID").
+type configCheckFailure struct {
+	JSONPath string `json:"jsonPath"`
+	Expected string `json:"expected"`
+	Message  string `json:"message"`
+}
+
+// configCheckReport is the structured YAML document printed to stderr when
+// `--check-config` finds problems. It satisfies the error interface so the
+// caller in main can treat it like any other error while still being able to
+// marshal it for a human/CI-readable report.
+type configCheckReport struct {
+	Failures []configCheckFailure `json:"failures"`
+}
+
+func (r *configCheckReport) Error() string {
+	return fmt.Sprintf("%d subsystem(s) failed config check", len(r.Failures))
+}
+
+func (r *configCheckReport) add(jsonPath, expected, message string) {
+	r.Failures = append(r.Failures, configCheckFailure{
+		JSONPath: jsonPath,
+		Expected: expected,
+		Message:  message,
+	})
+}
+
+// runConfigCheck checks the configured providers, upstreams, cookie secret,
+// session store, TLS files and header injectors in opts without opening
+// listening sockets. It checks field values and file paths only; none of
+// these subsystems is constructed. When mode is "online" it also fetches
+// each OIDC issuer's discovery document; otherwise it makes no network calls.
+func runConfigCheck(opts *options.Options, mode string) error {
+	online := mode == "online"
+	report := &configCheckReport{}
+
+	checkProviders(opts, online, report)
+	checkUpstreams(opts, report)
+	checkCookieSecret(opts, report)
+	checkSessionStore(opts, report)
+	checkTLS(opts, report)
+	checkHeaderInjectors(opts, report)
+
+	if len(report.Failures) == 0 {
+		return nil
+	}
+	return report
+}
+
+func checkProviders(opts *options.Options, online bool, report *configCheckReport) {
+	for i, provider := range opts.Providers {
+		path := fmt.Sprintf("providers[%d]", i)
+		if provider.ClientID == "" {
+			report.add(path+".clientID", "non-empty string", "client ID is required")
+		}
+		if provider.ClientSecret == "" && provider.ClientSecretFile == "" {
+			report.add(path+".clientSecret", "non-empty string", "either clientSecret or clientSecretFile must be set")
+		}
+		if online && provider.OIDCConfig.IssuerURL != "" {
+			if err := dryDiscoverOIDCIssuer(provider.OIDCConfig.IssuerURL); err != nil {
+				report.add(path+".oidcConfig.issuerURL", "reachable OIDC discovery document", err.Error())
+			}
+		}
+	}
+}
+
+func checkUpstreams(opts *options.Options, report *configCheckReport) {
+	for i, upstream := range opts.UpstreamConfig.Upstreams {
+		path := fmt.Sprintf("upstreamConfig.upstreams[%d]", i)
+		if upstream.Static {
+			continue
+		}
+		if _, err := url.Parse(upstream.URI); err != nil {
+			report.add(path+".uri", "a valid URL", err.Error())
+		}
+	}
+}
+
+func checkCookieSecret(opts *options.Options, report *configCheckReport) {
+	decoded, err := decodeCookieSecret(opts.Cookie.Secret)
+	if err != nil {
+		report.add("cookie.secret", "base64-encoded string", err.Error())
+		return
+	}
+	switch len(decoded) {
+	case 16, 24, 32:
+		return
+	default:
+		report.add("cookie.secret", "16, 24 or 32 bytes once base64-decoded", fmt.Sprintf("cookie secret must decode to an AES-128, AES-192 or AES-256 key, got %d bytes", len(decoded)))
+	}
+}
+
+// decodeCookieSecret mirrors how the cookie secret is actually consumed:
+// operators are told to generate it with e.g. `openssl rand -base64 32`, so
+// the configured value is base64 (standard or URL-safe), not raw bytes.
+func decodeCookieSecret(secret string) ([]byte, error) {
+	if decoded, err := base64.StdEncoding.DecodeString(secret); err == nil {
+		return decoded, nil
+	}
+	decoded, err := base64.URLEncoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("could not base64-decode cookie secret: %v", err)
+	}
+	return decoded, nil
+}
+
+func checkSessionStore(opts *options.Options, report *configCheckReport) {
+	if opts.Session.Type != options.RedisSessionStoreType {
+		return
+	}
+	if opts.Session.Redis.ConnectionURL == "" && len(opts.Session.Redis.ClusterConnectionURLs) == 0 {
+		report.add("session.redis.connectionURL", "non-empty string", "a redis connection URL is required when session.type is redis")
+	}
+}
+
+func checkTLS(opts *options.Options, report *configCheckReport) {
+	if opts.Server.TLS == nil {
+		return
+	}
+	if opts.Server.TLS.Key.FromFile != "" {
+		if _, err := os.Stat(opts.Server.TLS.Key.FromFile); err != nil {
+			report.add("server.tls.key.fromFile", "readable file path", err.Error())
+		}
+	}
+	if opts.Server.TLS.Cert.FromFile != "" {
+		if _, err := os.Stat(opts.Server.TLS.Cert.FromFile); err != nil {
+			report.add("server.tls.cert.fromFile", "readable file path", err.Error())
+		}
+	}
+}
+
+func checkHeaderInjectors(opts *options.Options, report *configCheckReport) {
+	for i, header := range opts.InjectRequestHeaders {
+		if header.Name == "" {
+			report.add(fmt.Sprintf("injectRequestHeaders[%d].name", i), "non-empty string", "header name is required")
+		}
+	}
+	for i, header := range opts.InjectResponseHeaders {
+		if header.Name == "" {
+			report.add(fmt.Sprintf("injectResponseHeaders[%d].name", i), "non-empty string", "header name is required")
+		}
+	}
+}
+
+// dryDiscoverOIDCIssuer is only called when `--check-config=online` is
+// passed. It fetches the issuer's OIDC discovery document over the network
+// so a reachability/misconfiguration problem is caught before rollout;
+// offline runs never reach this far, keeping `--check-config` usable in
+// air-gapped CI.
+func dryDiscoverOIDCIssuer(issuerURL string) error {
+	if _, err := url.ParseRequestURI(issuerURL); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), oidcDiscoveryTimeout)
+	defer cancel()
+
+	discoveryURL := strings.TrimSuffix(issuerURL, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not reach OIDC discovery document at %s: %v", discoveryURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("OIDC discovery document at %s returned HTTP %d", discoveryURL, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/oauth2-proxy/oauth2-proxy/v7/pkg/apis/options"
+)
+
+func TestDecodeCookieSecret(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef") // 32 bytes
+	key32 := key[:32]
+
+	std := base64.StdEncoding.EncodeToString(key32)
+	decoded, err := decodeCookieSecret(std)
+	if err != nil {
+		t.Fatalf("decodeCookieSecret(%q): %v", std, err)
+	}
+	if len(decoded) != 32 {
+		t.Errorf("got %d bytes, want 32", len(decoded))
+	}
+
+	urlSafe := base64.URLEncoding.EncodeToString(key32)
+	if _, err := decodeCookieSecret(urlSafe); err != nil {
+		t.Errorf("decodeCookieSecret(%q): %v", urlSafe, err)
+	}
+
+	if _, err := decodeCookieSecret("not-valid-base64!!!"); err == nil {
+		t.Error("expected an error for non-base64 input")
+	}
+}
+
+func TestDecodeCookieSecretRejectsWrongKeyLength(t *testing.T) {
+	tooShort := base64.StdEncoding.EncodeToString([]byte("short"))
+	decoded, err := decodeCookieSecret(tooShort)
+	if err != nil {
+		t.Fatalf("decodeCookieSecret(%q): %v", tooShort, err)
+	}
+	if len(decoded) == 16 || len(decoded) == 24 || len(decoded) == 32 {
+		t.Fatalf("test fixture accidentally decodes to a valid AES key length: %d", len(decoded))
+	}
+
+	opts := &options.Options{}
+	opts.Cookie.Secret = tooShort
+	report := &configCheckReport{}
+	checkCookieSecret(opts, report)
+	if len(report.Failures) != 1 || report.Failures[0].JSONPath != "cookie.secret" {
+		t.Errorf("checkCookieSecret failures = %v, want a single cookie.secret failure", report.Failures)
+	}
+}
+
+func TestDryDiscoverOIDCIssuerSucceedsAgainstReachableDiscoveryDocument(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"issuer":"` + r.Host + `"}`))
+	}))
+	defer srv.Close()
+
+	if err := dryDiscoverOIDCIssuer(srv.URL); err != nil {
+		t.Errorf("dryDiscoverOIDCIssuer(%q) = %v, want nil", srv.URL, err)
+	}
+}
+
+func TestDryDiscoverOIDCIssuerFailsAgainstUnreachableIssuer(t *testing.T) {
+	if err := dryDiscoverOIDCIssuer("http://127.0.0.1:0"); err == nil {
+		t.Error("expected an error for an unreachable issuer")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"math/rand"
 	"os"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/ghodss/yaml"
@@ -17,6 +18,19 @@ import (
 	"k8s.io/klog/v2"
 )
 
+// loggingSensitiveFields lists the observed-configuration keys (as they
+// appear after json.Marshal, i.e. following each field's `json:` tag, not
+// its Go identifier) that must be redacted from the startup trace dump when
+// SanitizeSecrets is enabled.
+var loggingSensitiveFields = map[string]bool{
+	"secret":           true, // cookie.secret
+	"clientSecret":     true, // providers[].clientSecret
+	"clientSecretFile": true, // providers[].clientSecretFile
+	"password":         true, // session.redis.password
+	"sentinelPassword": true, // session.redis.sentinelPassword
+	"clusterPassword":  true, // session.redis.clusterPassword
+}
+
 func main() {
 	logger.SetFlags(logger.Lshortfile)
 
@@ -27,33 +41,54 @@ func main() {
 	configFlagSet.ParseErrorsWhitelist.UnknownFlags = true
 
 	config := configFlagSet.String("config", "", "path to config file")
-	alphaConfig := configFlagSet.String("alpha-config", "", "path to alpha config file (use at your own risk - the structure in this config file may change between minor releases)")
+	alphaConfig := configFlagSet.StringArray("alpha-config", nil, "path to an alpha config file, or a directory of them (use at your own risk - the structure in this config file may change between minor releases); repeatable, merged in the order given")
+	alphaConfigMerge := configFlagSet.String("alpha-config-merge", "strict", "how to resolve conflicting scalars when merging multiple --alpha-config files/directories: strict (default, fail) or override (last file wins)")
 	convertConfig := configFlagSet.Bool("convert-config-to-alpha", false, "if true, the proxy will load configuration as normal and convert existing configuration to the alpha config structure, and print it to stdout")
 	showVersion := configFlagSet.Bool("version", false, "print version string")
 	logLevel := configFlagSet.Int("log-level", 0, "standard logging level (higher numbers will be more verbose)")
+	configFlagSet.String("logging-format", "text", "log output format: text, json or logfmt")
+	configFlagSet.Duration("log-flush-frequency", 5*time.Second, "maximum time between log flushes")
+	checkConfig := configFlagSet.String("check-config", "", "validate the configuration and exit without starting the proxy: offline (default, no network calls) or online (also performs OIDC discovery)")
+	configFlagSet.Lookup("check-config").NoOptDefVal = "offline"
+	watchConfig := configFlagSet.Bool("watch-config", false, "watch --config/--alpha-config for changes and hot-reload in addition to reloading on SIGHUP")
 	configFlagSet.Parse(os.Args[1:])
 
-	configureKlog(*logLevel)
+	loggingConfig, err := loadLoggingConfiguration(configFlagSet, *alphaConfig, *alphaConfigMerge)
+	if err != nil {
+		klog.Fatalf("ERROR: %v", err)
+	}
+	if errs := validation.ValidateLogging(loggingConfig); len(errs) > 0 {
+		klog.Fatalf("invalid logging configuration: %s", strings.Join(errs, "; "))
+	}
+
+	// skip_headers must be left on only for the default text format:
+	// ConfigureStructuredOutput re-derives ts/level/caller for json/logfmt by
+	// parsing klog's own header line, so klog has to keep emitting it.
+	configureKlog(*logLevel, loggingConfig.Format)
+
+	if err := logger.ConfigureStructuredOutput(loggingConfig); err != nil {
+		klog.Fatalf("ERROR: invalid logging configuration: %v", err)
+	}
 
 	if *showVersion {
 		fmt.Printf("oauth2-proxy %s (built with %s)\n", VERSION, runtime.Version())
 		return
 	}
 
-	if *convertConfig && *alphaConfig != "" {
+	if *convertConfig && len(*alphaConfig) > 0 {
 		logger.Fatal("cannot use alpha-config and conver-config-to-alpha together")
 	}
 
-	opts, err := loadConfiguration(*config, *alphaConfig, configFlagSet, os.Args[1:])
+	opts, err := loadConfiguration(*config, *alphaConfig, *alphaConfigMerge, configFlagSet, os.Args[1:])
 	if err != nil {
 		klog.Fatalf("ERROR: %v", err)
 	}
 
 	// When running with trace logging, start by logging the observed config.
 	// This will help users to determine if they have configured the proxy correctly.
-	// NOTE: This data is not scrubbed and may contain secrets!
+	// NOTE: Unless SanitizeSecrets is enabled, this data is not scrubbed and may contain secrets!
 	if traceLogger.Enabled() {
-		config, err := json.Marshal(opts)
+		config, err := json.Marshal(sanitizedObservedConfig(opts, loggingConfig))
 		if err != nil {
 			klog.Fatalf("ERROR: %v", err)
 		}
@@ -71,6 +106,19 @@ func main() {
 		klog.Fatalf("%s", err)
 	}
 
+	if *checkConfig != "" {
+		if err := runConfigCheck(opts, *checkConfig); err != nil {
+			report, marshalErr := yaml.Marshal(err)
+			if marshalErr != nil {
+				klog.Fatalf("ERROR: config check failed: %v", err)
+			}
+			fmt.Fprintln(os.Stderr, string(report))
+			os.Exit(1)
+		}
+		fmt.Println("configuration is valid")
+		return
+	}
+
 	validator := NewValidator(opts.EmailDomains, opts.AuthenticatedEmailsFile)
 	oauthproxy, err := NewOAuthProxy(opts, validator)
 	if err != nil {
@@ -79,18 +127,64 @@ func main() {
 
 	rand.Seed(time.Now().UnixNano())
 
-	if err := oauthproxy.Start(); err != nil {
+	reloader := newReloadableProxy(oauthproxy, opts.Server)
+	reloadCfg := reloadConfig{
+		config:           *config,
+		alphaConfigPaths: *alphaConfig,
+		alphaConfigMerge: *alphaConfigMerge,
+		extraFlags:       configFlagSet,
+		args:             os.Args[1:],
+		watch:            *watchConfig,
+	}
+	reloader.watchForReload(reloadCfg)
+
+	if err := reloader.Start(); err != nil {
 		klog.Fatalf("ERROR: Failed to start OAuth2 Proxy: %v", err)
 	}
 }
 
+// loadLoggingConfiguration builds the effective LoggingConfiguration from the
+// --logging-format/--log-level/--log-flush-frequency flags in flags,
+// overridden by the `logging:` block of any --alpha-config file(s) given.
+// It is used both at startup and on every reload; a reload re-applies only
+// the structured-output part of the result (see reloadableProxy.reload).
+func loadLoggingConfiguration(flags *pflag.FlagSet, alphaConfigPaths []string, alphaConfigMerge string) (*options.LoggingConfiguration, error) {
+	format, err := flags.GetString("logging-format")
+	if err != nil {
+		return nil, err
+	}
+	logLevel, err := flags.GetInt("log-level")
+	if err != nil {
+		return nil, err
+	}
+	flushFrequency, err := flags.GetDuration("log-flush-frequency")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &options.LoggingConfiguration{
+		Format:         format,
+		Verbosity:      int32(logLevel),
+		FlushFrequency: flushFrequency,
+	}
+
+	alphaLogging, err := loadAlphaLoggingConfiguration(alphaConfigPaths, alphaConfigMerge)
+	if err != nil {
+		return nil, err
+	}
+	if alphaLogging != nil {
+		cfg = alphaLogging
+	}
+	return cfg, nil
+}
+
 // loadConfiguration will load in the user's configuration.
-// It will either load the alpha configuration (if alphaConfig is given)
+// It will either load the alpha configuration (if alphaConfigPaths is given)
 // or the legacy configuration.
-func loadConfiguration(config, alphaConfig string, extraFlags *pflag.FlagSet, args []string) (*options.Options, error) {
-	if alphaConfig != "" {
+func loadConfiguration(config string, alphaConfigPaths []string, alphaConfigMerge string, extraFlags *pflag.FlagSet, args []string) (*options.Options, error) {
+	if len(alphaConfigPaths) > 0 {
 		klog.Warningf("WARNING: You are using alpha configuration. The structure in this configuration file may change without notice. You MUST remove conflicting options from your existing configuration.")
-		return loadAlphaOptions(config, alphaConfig, extraFlags, args)
+		return loadAlphaOptions(config, alphaConfigPaths, alphaConfigMerge, extraFlags, args)
 	}
 	return loadLegacyOptions(config, extraFlags, args)
 }
@@ -118,16 +212,16 @@ func loadLegacyOptions(config string, extraFlags *pflag.FlagSet, args []string)
 }
 
 // loadAlphaOptions loads the old style config excluding options converted to
-// the new alpha format, then merges the alpha options, loaded from YAML,
-// into the core configuration.
-func loadAlphaOptions(config, alphaConfig string, extraFlags *pflag.FlagSet, args []string) (*options.Options, error) {
+// the new alpha format, then merges the alpha options, loaded from one or
+// more YAML files or directories, into the core configuration.
+func loadAlphaOptions(config string, alphaConfigPaths []string, alphaConfigMerge string, extraFlags *pflag.FlagSet, args []string) (*options.Options, error) {
 	opts, err := loadOptions(config, extraFlags, args)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load core options: %v", err)
 	}
 
-	alphaOpts := &options.AlphaOptions{}
-	if err := options.LoadYAML(alphaConfig, alphaOpts); err != nil {
+	alphaOpts, err := loadMergedAlphaOptions(alphaConfigPaths, alphaConfigMerge)
+	if err != nil {
 		return nil, fmt.Errorf("failed to load alpha options: %v", err)
 	}
 
@@ -172,13 +266,69 @@ func printConvertedConfig(opts *options.Options) error {
 	return nil
 }
 
+// sanitizedObservedConfig returns opts ready for the startup trace dump,
+// redacting well-known secret fields first when the loaded logging
+// configuration has SanitizeSecrets enabled.
+func sanitizedObservedConfig(opts *options.Options, loggingConfig *options.LoggingConfiguration) interface{} {
+	if loggingConfig == nil || !loggingConfig.SanitizeSecrets {
+		return opts
+	}
+
+	raw, err := json.Marshal(opts)
+	if err != nil {
+		return opts
+	}
+
+	var observed interface{}
+	if err := json.Unmarshal(raw, &observed); err != nil {
+		return opts
+	}
+
+	redactSensitiveFields(observed)
+	return observed
+}
+
+// redactSensitiveFields walks an observed-configuration value (as produced
+// by json.Unmarshal into interface{}, i.e. made up of map[string]interface{},
+// []interface{} and scalars) and replaces the value of any object key in
+// loggingSensitiveFields with a fixed placeholder, recursing through both
+// nested objects and arrays (e.g. the providers[] list, which is where
+// clientSecret actually lives).
+func redactSensitiveFields(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if loggingSensitiveFields[key] {
+				v[key] = "<redacted>"
+				continue
+			}
+			redactSensitiveFields(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			redactSensitiveFields(child)
+		}
+	}
+}
+
 // configureKlog congiures the klog library to write its output to the OAuth2
 // Proxy logger package. This allows us to use the interfaces but retain the
 // formatting configured by our built in logger library.
-func configureKlog(logLevel int) {
+//
+// format is the resolved LoggingConfiguration.Format: for "json"/"logfmt",
+// klog's header line (ts/level/caller) must keep being emitted because
+// logger.ConfigureStructuredOutput parses it back out of each line; for the
+// default "text" format the header is redundant with our own formatting and
+// stays suppressed.
+func configureKlog(logLevel int, format string) {
 	klogFlags := flag.NewFlagSet("klog", flag.ExitOnError)
 	klog.InitFlags(klogFlags)
 
+	skipHeaders := "true"
+	if format == logger.FormatJSON || format == logger.FormatLogfmt {
+		skipHeaders = "false"
+	}
+
 	// If any of the following fail, this is a programming error
 	if err := klogFlags.Lookup("logtostderr").Value.Set("false"); err != nil {
 		panic(err)
@@ -186,7 +336,7 @@ func configureKlog(logLevel int) {
 	if err := klogFlags.Lookup("one_output").Value.Set("true"); err != nil {
 		panic(err)
 	}
-	if err := klogFlags.Lookup("skip_headers").Value.Set("true"); err != nil {
+	if err := klogFlags.Lookup("skip_headers").Value.Set(skipHeaders); err != nil {
 		panic(err)
 	}
 
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"k8s.io/klog/v2"
+)
+
+func newTestLoggingFlagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("logging-format", "text", "")
+	flags.Int("log-level", 0, "")
+	flags.Duration("log-flush-frequency", 5*time.Second, "")
+	return flags
+}
+
+func TestLoadLoggingConfigurationFromFlags(t *testing.T) {
+	flags := newTestLoggingFlagSet()
+	if err := flags.Set("logging-format", "json"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	cfg, err := loadLoggingConfiguration(flags, nil, "strict")
+	if err != nil {
+		t.Fatalf("loadLoggingConfiguration: %v", err)
+	}
+	if cfg.Format != "json" {
+		t.Errorf("Format = %q, want %q", cfg.Format, "json")
+	}
+}
+
+func TestLoadLoggingConfigurationAlphaConfigOverridesFlags(t *testing.T) {
+	dir := t.TempDir()
+	alpha := filepath.Join(dir, "alpha.yaml")
+	if err := os.WriteFile(alpha, []byte("logging:\n  format: logfmt\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	flags := newTestLoggingFlagSet()
+	if err := flags.Set("logging-format", "json"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	cfg, err := loadLoggingConfiguration(flags, []string{alpha}, "strict")
+	if err != nil {
+		t.Fatalf("loadLoggingConfiguration: %v", err)
+	}
+	if cfg.Format != "logfmt" {
+		t.Errorf("Format = %q, want the alpha config's %q to win over the --logging-format flag", cfg.Format, "logfmt")
+	}
+}
+
+// klogHeaderPattern matches klog's own line-prefix format, e.g.
+// "I0725 12:34:56.789012   42 main.go:49] hello" - the same shape
+// pkg/logger's klogLinePattern parses back out of each line.
+var klogHeaderPattern = regexp.MustCompile(`^[IWEF]\d{4} \d{2}:\d{2}:\d{2}\.\d{6}\s+\d+ \S+:\d+\]`)
+
+// TestConfigureKlogHeadersFollowFormat exercises the real klog.SetOutput
+// pipeline (not just logger.parseKlogLine in isolation) to confirm
+// configureKlog keeps klog's header line enabled for the structured formats
+// that logger.ConfigureStructuredOutput parses it back out of, and disabled
+// for the default text format where it would just be noise.
+func TestConfigureKlogHeadersFollowFormat(t *testing.T) {
+	for _, tc := range []struct {
+		format     string
+		wantHeader bool
+	}{
+		{format: "text", wantHeader: false},
+		{format: "json", wantHeader: true},
+		{format: "logfmt", wantHeader: true},
+	} {
+		configureKlog(0, tc.format)
+
+		var buf bytes.Buffer
+		klog.SetOutput(&buf)
+		klog.SetOutputBySeverity("INFO", &buf)
+		klog.Info("hello")
+		klog.Flush()
+
+		if got := klogHeaderPattern.MatchString(buf.String()); got != tc.wantHeader {
+			t.Errorf("format %q: klog header present = %v, want %v (line: %q)", tc.format, got, tc.wantHeader, buf.String())
+		}
+	}
+}
+
+func TestRedactSensitiveFieldsRecursesIntoArrays(t *testing.T) {
+	var observed interface{}
+	raw := `{
+		"cookie": {"secret": "topsecret"},
+		"providers": [
+			{"clientID": "abc", "clientSecret": "shh", "clientSecretFile": "/etc/secret"}
+		],
+		"session": {"redis": {"password": "hunter2", "connectionURL": "redis://localhost:6379"}}
+	}`
+	if err := json.Unmarshal([]byte(raw), &observed); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	redactSensitiveFields(observed)
+
+	m := observed.(map[string]interface{})
+	if got := m["cookie"].(map[string]interface{})["secret"]; got != "<redacted>" {
+		t.Errorf("cookie.secret = %v, want <redacted>", got)
+	}
+
+	provider := m["providers"].([]interface{})[0].(map[string]interface{})
+	if got := provider["clientSecret"]; got != "<redacted>" {
+		t.Errorf("providers[0].clientSecret = %v, want <redacted>", got)
+	}
+	if got := provider["clientSecretFile"]; got != "<redacted>" {
+		t.Errorf("providers[0].clientSecretFile = %v, want <redacted>", got)
+	}
+	if got := provider["clientID"]; got != "abc" {
+		t.Errorf("providers[0].clientID = %v, want unchanged", got)
+	}
+
+	redis := m["session"].(map[string]interface{})["redis"].(map[string]interface{})
+	if got := redis["password"]; got != "<redacted>" {
+		t.Errorf("session.redis.password = %v, want <redacted>", got)
+	}
+	if got := redis["connectionURL"]; got != "redis://localhost:6379" {
+		t.Errorf("session.redis.connectionURL = %v, want unchanged", got)
+	}
+}
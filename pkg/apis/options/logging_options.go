@@ -0,0 +1,48 @@
+package options
+
+import "time"
+
+// LoggingConfiguration controls how the proxy's own logs, and the klog
+// bridge used for internal component logging, are formatted and flushed.
+// It mirrors the shape of k8s.io/component-base/logs/api/v1's
+// LoggingConfiguration so operators already familiar with that convention
+// can configure oauth2-proxy the same way.
+type LoggingConfiguration struct {
+	// Format is the log output format: "text" (default), "json" or "logfmt".
+	Format string `json:"format,omitempty"`
+	// Verbosity holds the --log-level value when built from flags. klog's
+	// verbosity is always set from --log-level; a verbosity given in an alpha
+	// config's logging block is read but not applied.
+	Verbosity int32 `json:"verbosity,omitempty"`
+	// FlushFrequency is the maximum time between log flushes.
+	FlushFrequency time.Duration `json:"flushFrequency,omitempty"`
+	// SanitizeSecrets redacts known-sensitive fields (cookie secret, client
+	// secrets, redis passwords) from the startup observed-configuration
+	// trace dump.
+	SanitizeSecrets bool `json:"sanitizeSecrets,omitempty"`
+	// Options holds format-specific tuning.
+	Options LoggingOptions `json:"options,omitempty"`
+}
+
+// LoggingOptions holds format-specific tuning for LoggingConfiguration.
+type LoggingOptions struct {
+	JSON JSONOptions `json:"json,omitempty"`
+}
+
+// JSONOptions tunes the "json" LoggingConfiguration.Format.
+type JSONOptions struct {
+	// SplitStream routes Info logs to stdout and Warning/Error/Fatal logs
+	// to stderr when true; otherwise all levels share a single stream.
+	SplitStream bool `json:"splitStream,omitempty"`
+	// InfoBufferSize is the size, in bytes, of the buffered writer used
+	// for Info logs when SplitStream is enabled. Zero disables buffering.
+	InfoBufferSize int `json:"infoBufferSize,omitempty"`
+}
+
+// NewLoggingConfiguration returns the proxy's default LoggingConfiguration.
+func NewLoggingConfiguration() LoggingConfiguration {
+	return LoggingConfiguration{
+		Format:         "text",
+		FlushFrequency: 5 * time.Second,
+	}
+}
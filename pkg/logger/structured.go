@@ -0,0 +1,220 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oauth2-proxy/oauth2-proxy/v7/pkg/apis/options"
+	"k8s.io/klog/v2"
+)
+
+// Supported LoggingConfiguration.Format values.
+const (
+	FormatText   = "text"
+	FormatJSON   = "json"
+	FormatLogfmt = "logfmt"
+)
+
+// klogLinePattern matches klog's default text line prefix, e.g.
+// "I0725 12:34:56.789012   42 main.go:49] request complete client_ip=1.2.3.4 status=200".
+var klogLinePattern = regexp.MustCompile(`^([IWEF])\d{4} (\d{2}:\d{2}:\d{2}\.\d{6})\s+\d+ (\S+:\d+)\] (.*)$`)
+
+// fieldPattern picks out trailing key=value (or key="quoted value") tokens
+// from a klog message so that fields logged by the request/auth loggers
+// (client_ip, user, upstream, status, ...) survive into structured output
+// as first-class JSON/logfmt fields rather than being stuck inside msg.
+var fieldPattern = regexp.MustCompile(`(\w+)=("(?:[^"\\]|\\.)*"|\S+)`)
+
+var severityNames = map[string]string{
+	"I": "info",
+	"W": "warning",
+	"E": "error",
+	"F": "fatal",
+}
+
+// ConfigureStructuredOutput validates cfg and, for "json"/"logfmt", rewires
+// klog's output (previously pointed at StdKlogErrorLogger/StdKlogInfoLogger
+// by configureKlog) through a writer that parses each klog line back into
+// ts/level/msg/caller fields, plus any key=value pairs already present in
+// the message, and re-emits one structured record per line. "text" (the
+// default) leaves klog's own formatting untouched.
+func ConfigureStructuredOutput(cfg *options.LoggingConfiguration) error {
+	if cfg == nil || cfg.Format == "" || cfg.Format == FormatText {
+		return nil
+	}
+
+	var encode func(map[string]interface{}) []byte
+	switch cfg.Format {
+	case FormatJSON:
+		encode = encodeJSONLine
+	case FormatLogfmt:
+		encode = encodeLogfmtLine
+	default:
+		return fmt.Errorf("unknown logging format %q: must be one of %s, %s, %s", cfg.Format, FormatText, FormatJSON, FormatLogfmt)
+	}
+
+	errOut := newStructuredWriter(StdKlogErrorLogger, encode)
+	var infoOut io.Writer = errOut
+	if cfg.Format == FormatJSON && cfg.Options.JSON.SplitStream {
+		infoOut = newBufferedStructuredWriter(StdKlogInfoLogger, encode, cfg.Options.JSON.InfoBufferSize, cfg.FlushFrequency)
+	}
+
+	klog.SetOutput(errOut)
+	klog.SetOutputBySeverity("INFO", infoOut)
+	return nil
+}
+
+// structuredWriter reformats each line written to it via encode before
+// forwarding it to out. It is safe for concurrent use, matching the klog
+// output contract.
+type structuredWriter struct {
+	mu     sync.Mutex
+	out    io.Writer
+	encode func(map[string]interface{}) []byte
+}
+
+func newStructuredWriter(out io.Writer, encode func(map[string]interface{}) []byte) *structuredWriter {
+	return &structuredWriter{out: out, encode: encode}
+}
+
+func (w *structuredWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		record := append(w.encode(parseKlogLine(line)), '\n')
+		if _, err := w.out.Write(record); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *structuredWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if f, ok := w.out.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// bufferedStructuredWriter is a structuredWriter whose underlying writer is
+// buffered and flushed on a timer, implementing FlushFrequency/InfoBufferSize.
+type bufferedStructuredWriter struct {
+	*structuredWriter
+}
+
+// newBufferedStructuredWriter wraps out in a bufio.Writer sized bufferSize
+// and, if flushFrequency is set, flushes it on a timer. A bufferSize of zero
+// disables buffering entirely (per LoggingOptions.JSON.InfoBufferSize's doc)
+// rather than falling back to some default size.
+func newBufferedStructuredWriter(out io.Writer, encode func(map[string]interface{}) []byte, bufferSize int, flushFrequency time.Duration) *bufferedStructuredWriter {
+	if bufferSize == 0 {
+		return &bufferedStructuredWriter{structuredWriter: newStructuredWriter(out, encode)}
+	}
+
+	buffered := bufio.NewWriterSize(out, bufferSize)
+	w := &bufferedStructuredWriter{structuredWriter: newStructuredWriter(buffered, encode)}
+
+	if flushFrequency > 0 {
+		go w.flushPeriodically(flushFrequency)
+	}
+	return w
+}
+
+func (w *bufferedStructuredWriter) flushPeriodically(d time.Duration) {
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+	for range ticker.C {
+		_ = w.Flush()
+	}
+}
+
+// parseKlogLine extracts ts/level/caller/msg from a klog-formatted line,
+// plus any key=value pairs found in msg, promoted to top-level fields.
+// Lines that don't match klog's format (e.g. a line already written by a
+// caller that bypasses klog) are passed through verbatim as msg.
+func parseKlogLine(line []byte) map[string]interface{} {
+	fields := map[string]interface{}{}
+
+	msg := string(line)
+	if m := klogLinePattern.FindSubmatch(line); m != nil {
+		fields["level"] = severityNames[string(m[1])]
+		fields["ts"] = string(m[2])
+		fields["caller"] = string(m[3])
+		msg = string(m[4])
+	}
+	fields["msg"] = msg
+
+	for _, m := range fieldPattern.FindAllStringSubmatch(msg, -1) {
+		key, value := m[1], strings.Trim(m[2], `"`)
+		if _, reserved := fields[key]; reserved {
+			continue
+		}
+		fields[key] = value
+	}
+	return fields
+}
+
+// encodeJSONLine marshals fields as a single-line JSON object.
+func encodeJSONLine(fields map[string]interface{}) []byte {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return []byte(fmt.Sprintf(`{"level":"error","msg":"failed to marshal log line: %s"}`, err))
+	}
+	return data
+}
+
+// logfmtFieldOrder lists the fields that, when present, are always emitted
+// first and in this order; any remaining fields follow sorted by key.
+var logfmtFieldOrder = []string{"ts", "level", "caller", "msg"}
+
+// encodeLogfmtLine renders fields as space-separated key=value pairs,
+// quoting any value that contains whitespace.
+func encodeLogfmtLine(fields map[string]interface{}) []byte {
+	seen := make(map[string]bool, len(logfmtFieldOrder))
+	var parts []string
+
+	for _, key := range logfmtFieldOrder {
+		value, ok := fields[key]
+		if !ok {
+			continue
+		}
+		seen[key] = true
+		parts = append(parts, logfmtPair(key, value))
+	}
+
+	var rest []string
+	for key := range fields {
+		if !seen[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	for _, key := range rest {
+		parts = append(parts, logfmtPair(key, fields[key]))
+	}
+
+	return []byte(strings.Join(parts, " "))
+}
+
+func logfmtPair(key string, value interface{}) string {
+	str := fmt.Sprintf("%v", value)
+	if strings.ContainsAny(str, " \t\"") {
+		str = strconv.Quote(str)
+	}
+	return key + "=" + str
+}
@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseKlogLine(t *testing.T) {
+	line := []byte(`I0725 12:34:56.789012      42 main.go:49] request complete client_ip=1.2.3.4 status=200`)
+	fields := parseKlogLine(line)
+
+	for key, want := range map[string]string{
+		"level":     "info",
+		"ts":        "12:34:56.789012",
+		"caller":    "main.go:49",
+		"client_ip": "1.2.3.4",
+		"status":    "200",
+	} {
+		if got, _ := fields[key].(string); got != want {
+			t.Errorf("fields[%q] = %q, want %q", key, got, want)
+		}
+	}
+	if msg, _ := fields["msg"].(string); msg != "request complete client_ip=1.2.3.4 status=200" {
+		t.Errorf("msg = %q", msg)
+	}
+}
+
+func TestParseKlogLineNonKlogFormat(t *testing.T) {
+	fields := parseKlogLine([]byte("a plain line with no klog prefix"))
+	if _, ok := fields["level"]; ok {
+		t.Error("expected no level field for a non-klog-formatted line")
+	}
+	if fields["msg"] != "a plain line with no klog prefix" {
+		t.Errorf("msg = %v", fields["msg"])
+	}
+}
+
+func TestEncodeJSONLine(t *testing.T) {
+	data := encodeJSONLine(map[string]interface{}{"level": "info", "msg": "hello"})
+
+	var decoded map[string]string
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("encodeJSONLine produced invalid JSON: %v", err)
+	}
+	if decoded["level"] != "info" || decoded["msg"] != "hello" {
+		t.Errorf("decoded = %v", decoded)
+	}
+}
+
+func TestEncodeLogfmtLineOrdersKnownFieldsFirst(t *testing.T) {
+	line := string(encodeLogfmtLine(map[string]interface{}{
+		"status": "200",
+		"msg":    "request complete",
+		"level":  "info",
+		"ts":     "12:00:00",
+	}))
+
+	want := `ts=12:00:00 level=info msg="request complete" status=200`
+	if line != want {
+		t.Errorf("encodeLogfmtLine() = %q, want %q", line, want)
+	}
+}
+
+func TestNewBufferedStructuredWriterZeroSizeDisablesBuffering(t *testing.T) {
+	var out bytes.Buffer
+	w := newBufferedStructuredWriter(&out, encodeJSONLine, 0, 0)
+
+	if _, err := w.Write([]byte("I0725 00:00:00.000000 1 a.go:1] unbuffered\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	// With buffering disabled the line must reach out immediately, without
+	// requiring a Flush call.
+	if !strings.Contains(out.String(), "unbuffered") {
+		t.Errorf("out = %q, want the line to be written without a Flush", out.String())
+	}
+}
+
+func TestStructuredWriterEmitsOneRecordPerLine(t *testing.T) {
+	var out bytes.Buffer
+	w := newStructuredWriter(&out, encodeJSONLine)
+
+	if _, err := w.Write([]byte("I0725 00:00:00.000000 1 a.go:1] first\nI0725 00:00:00.000000 1 a.go:2] second\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), out.String())
+	}
+	for i, want := range []string{"first", "second"} {
+		var decoded map[string]string
+		if err := json.Unmarshal([]byte(lines[i]), &decoded); err != nil {
+			t.Fatalf("line %d: %v", i, err)
+		}
+		if decoded["msg"] != want {
+			t.Errorf("line %d msg = %q, want %q", i, decoded["msg"], want)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package validation
+
+import (
+	"fmt"
+
+	"github.com/oauth2-proxy/oauth2-proxy/v7/pkg/apis/options"
+	"github.com/oauth2-proxy/oauth2-proxy/v7/pkg/logger"
+)
+
+// ValidateLogging checks the fields of a LoggingConfiguration, whether built
+// from the logging flags or loaded from an alpha config's top-level
+// `logging` block. A nil logging configuration is valid.
+func ValidateLogging(logging *options.LoggingConfiguration) []string {
+	if logging == nil {
+		return nil
+	}
+
+	var errs []string
+	switch logging.Format {
+	case "", logger.FormatText, logger.FormatJSON, logger.FormatLogfmt:
+	default:
+		errs = append(errs, fmt.Sprintf("logging.format must be one of %s, %s, %s: got %q",
+			logger.FormatText, logger.FormatJSON, logger.FormatLogfmt, logging.Format))
+	}
+
+	if logging.FlushFrequency < 0 {
+		errs = append(errs, "logging.flushFrequency must not be negative")
+	}
+
+	if logging.Options.JSON.InfoBufferSize < 0 {
+		errs = append(errs, "logging.options.json.infoBufferSize must not be negative")
+	}
+
+	return errs
+}
@@ -0,0 +1,44 @@
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oauth2-proxy/oauth2-proxy/v7/pkg/apis/options"
+)
+
+func TestValidateLoggingNil(t *testing.T) {
+	if errs := ValidateLogging(nil); len(errs) != 0 {
+		t.Errorf("expected no errors for nil logging configuration, got %v", errs)
+	}
+}
+
+func TestValidateLoggingRejectsUnknownFormat(t *testing.T) {
+	errs := ValidateLogging(&options.LoggingConfiguration{Format: "xml"})
+	if len(errs) != 1 {
+		t.Fatalf("expected exactly one error, got %v", errs)
+	}
+}
+
+func TestValidateLoggingRejectsNegativeValues(t *testing.T) {
+	errs := ValidateLogging(&options.LoggingConfiguration{
+		Format:         "json",
+		FlushFrequency: -time.Second,
+		Options: options.LoggingOptions{
+			JSON: options.JSONOptions{InfoBufferSize: -1},
+		},
+	})
+	if len(errs) != 2 {
+		t.Fatalf("expected exactly two errors, got %v", errs)
+	}
+}
+
+func TestValidateLoggingAcceptsValidConfiguration(t *testing.T) {
+	errs := ValidateLogging(&options.LoggingConfiguration{
+		Format:         "logfmt",
+		FlushFrequency: 5 * time.Second,
+	})
+	if len(errs) != 0 {
+		t.Errorf("expected no errors, got %v", errs)
+	}
+}
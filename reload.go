@@ -0,0 +1,265 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/oauth2-proxy/oauth2-proxy/v7/pkg/apis/options"
+	"github.com/oauth2-proxy/oauth2-proxy/v7/pkg/logger"
+	"github.com/oauth2-proxy/oauth2-proxy/v7/pkg/validation"
+	"github.com/spf13/pflag"
+	"k8s.io/klog/v2"
+)
+
+// reloadGracePeriod is how long a reload waits before releasing resources
+// held by the OAuthProxy instance it just replaced, giving requests already
+// in flight against it time to finish.
+const reloadGracePeriod = 30 * time.Second
+
+// reloadConfig carries everything reloadableProxy needs to re-run
+// loadConfiguration + validation.Validate from scratch on a reload trigger.
+type reloadConfig struct {
+	config           string
+	alphaConfigPaths []string
+	alphaConfigMerge string
+	extraFlags       *pflag.FlagSet
+	args             []string
+	watch            bool
+}
+
+// reloadableProxy serves requests through whichever *OAuthProxy was most
+// recently constructed, letting main.go swap in a freshly validated
+// instance on SIGHUP or a config file change without closing the listener
+// or dropping in-flight requests, which continue to be served by the
+// instance they started against.
+type reloadableProxy struct {
+	current    atomic.Pointer[OAuthProxy]
+	serverOpts options.Server
+	reloadMu   sync.Mutex // serializes concurrent SIGHUP/fsnotify reload triggers
+}
+
+func newReloadableProxy(initial *OAuthProxy, serverOpts options.Server) *reloadableProxy {
+	r := &reloadableProxy{serverOpts: serverOpts}
+	r.current.Store(initial)
+	return r
+}
+
+func (r *reloadableProxy) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	r.current.Load().ServeHTTP(rw, req)
+}
+
+// bindAddressDisabled reports whether a configured bind address means "don't
+// start this listener", matching the rest of the options package's
+// convention of using "-" (and, for symmetry, an empty string) to disable.
+func bindAddressDisabled(addr string) bool {
+	return addr == "" || addr == "-"
+}
+
+// Start begins serving r as the handler for the proxy's configured
+// listener(s). Both the plain and the TLS listener are started concurrently
+// when both are configured, matching the "-" disables each independently
+// convention: it blocks until the first listener exits, mirroring the
+// previous (*OAuthProxy).Start() contract, so main can keep treating its
+// return value the same way.
+func (r *reloadableProxy) Start() error {
+	plainAddr := r.serverOpts.BindAddress
+	tlsEnabled := r.serverOpts.TLS != nil && !bindAddressDisabled(r.serverOpts.SecureBindAddress)
+	plainEnabled := !bindAddressDisabled(plainAddr)
+
+	if !plainEnabled && !tlsEnabled {
+		return fmt.Errorf("no listener configured: both server.bindAddress and server.secureBindAddress/tls are disabled")
+	}
+
+	errc := make(chan error, 2)
+	if plainEnabled {
+		go func() {
+			errc <- (&http.Server{Addr: plainAddr, Handler: r}).ListenAndServe()
+		}()
+	}
+	if tlsEnabled {
+		go func() {
+			srv := &http.Server{Addr: r.serverOpts.SecureBindAddress, Handler: r}
+			errc <- srv.ListenAndServeTLS(r.serverOpts.TLS.Cert.FromFile, r.serverOpts.TLS.Key.FromFile)
+		}()
+	}
+
+	// Return as soon as either listener exits; the other keeps running until
+	// the process itself exits, matching the previous either/or behavior
+	// where a single listener's error was returned directly to main.
+	return <-errc
+}
+
+// watchForReload installs a SIGHUP handler, and an optional fsnotify watcher
+// when cfg.watch is set, that each re-run loadConfiguration + validation and
+// swap in a new OAuthProxy built from the result. A failed reload is logged
+// and the previously running instance keeps serving.
+func (r *reloadableProxy) watchForReload(cfg reloadConfig) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	go func() {
+		for range sighup {
+			logger.Printf("received SIGHUP, reloading configuration")
+			r.reload(cfg)
+		}
+	}()
+
+	if !cfg.watch {
+		return
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		klog.Errorf("ERROR: could not start config watcher, hot-reload on file change is disabled: %v", err)
+		return
+	}
+
+	// Watch the containing directory rather than the file itself: many
+	// config-management tools (and Kubernetes ConfigMap volume mounts) update
+	// a file by writing a temp file and renaming it over the target, which
+	// replaces the inode fsnotify was watching and silently ends the watch.
+	watchedNames, watchedDirs := watchedFilesAndDirs(cfg)
+	for dir := range watchedDirs {
+		if err := watcher.Add(dir); err != nil {
+			klog.Errorf("ERROR: could not watch %q for changes: %v", dir, err)
+		}
+	}
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+				// A path given directly is only relevant if it, specifically,
+				// changed; a path given as a directory should react to any
+				// file appearing or changing within it (e.g. new ConfigMap
+				// snippets under conf.d/).
+				if !watchedDirs[filepath.Dir(event.Name)] && !watchedNames[filepath.Base(event.Name)] {
+					continue
+				}
+				logger.Printf("detected change to %q, reloading configuration", event.Name)
+				r.reload(cfg)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				klog.Errorf("ERROR: config watcher error: %v", err)
+			}
+		}
+	}()
+}
+
+// watchedFilesAndDirs returns the basenames fsnotify events should trigger a
+// reload for, and the directories to watch, keyed by whether the directory
+// itself was given (true: any file change inside it reloads, e.g. a
+// conf.d/-style drop-in directory) or was only inferred from a file input
+// (false: only a change matching one of names reloads).
+func watchedFilesAndDirs(cfg reloadConfig) (names, dirs map[string]bool) {
+	names = map[string]bool{}
+	dirs = map[string]bool{}
+
+	watch := func(path string) {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			dirs[path] = true
+			return
+		}
+		names[filepath.Base(path)] = true
+		if !dirs[filepath.Dir(path)] {
+			dirs[filepath.Dir(path)] = false
+		}
+	}
+
+	if cfg.config != "" {
+		watch(cfg.config)
+	}
+	for _, path := range cfg.alphaConfigPaths {
+		watch(path)
+	}
+	return names, dirs
+}
+
+// reload re-runs configuration loading and validation, and on success
+// atomically swaps in a freshly constructed OAuthProxy. The previous
+// instance keeps serving any requests already in flight against it until
+// closeStaleProxy releases it after reloadGracePeriod.
+func (r *reloadableProxy) reload(cfg reloadConfig) {
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+
+	opts, err := loadConfiguration(cfg.config, cfg.alphaConfigPaths, cfg.alphaConfigMerge, cfg.extraFlags, cfg.args)
+	if err != nil {
+		klog.Errorf("ERROR: config reload failed, continuing with previous configuration: %v", err)
+		return
+	}
+
+	if err := validation.Validate(opts); err != nil {
+		klog.Errorf("ERROR: config reload failed validation, continuing with previous configuration: %v", err)
+		return
+	}
+
+	// Re-derive and re-validate the logging configuration too, rejecting an
+	// invalid one the same way startup does, and re-apply it through
+	// ConfigureStructuredOutput so a changed json/logfmt format or flush
+	// frequency takes effect. configureKlog is not re-run: klog's verbosity
+	// and header setting stay as set at startup, and a change back to the
+	// text format leaves the structured writers in place.
+	loggingConfig, err := loadLoggingConfiguration(cfg.extraFlags, cfg.alphaConfigPaths, cfg.alphaConfigMerge)
+	if err != nil {
+		klog.Errorf("ERROR: config reload failed to load logging configuration, continuing with previous configuration: %v", err)
+		return
+	}
+	if errs := validation.ValidateLogging(loggingConfig); len(errs) > 0 {
+		klog.Errorf("ERROR: config reload failed logging validation, continuing with previous configuration: %s", strings.Join(errs, "; "))
+		return
+	}
+
+	validator := NewValidator(opts.EmailDomains, opts.AuthenticatedEmailsFile)
+	newProxy, err := NewOAuthProxy(opts, validator)
+	if err != nil {
+		klog.Errorf("ERROR: config reload failed to initialise OAuth2 Proxy, continuing with previous configuration: %v", err)
+		return
+	}
+
+	if err := logger.ConfigureStructuredOutput(loggingConfig); err != nil {
+		klog.Errorf("ERROR: config reload failed to apply logging configuration, continuing with previous configuration: %v", err)
+		return
+	}
+
+	old := r.current.Swap(newProxy)
+	logger.Printf("configuration reloaded successfully")
+	go closeStaleProxy(old)
+}
+
+// closeStaleProxy waits reloadGracePeriod for requests already in flight
+// against old to finish, then releases resources it holds (e.g. its Redis
+// session-store connection pool) so repeated reloads don't leak one old
+// instance's worth of connections/goroutines per reload. OAuthProxy's own
+// shutdown method, if any, isn't visible from this package, so this calls
+// it through the same optional-interface pattern as io.Closer rather than
+// assuming a specific signature.
+func closeStaleProxy(old *OAuthProxy) {
+	if old == nil {
+		return
+	}
+	time.Sleep(reloadGracePeriod)
+
+	if closer, ok := interface{}(old).(interface{ Close() error }); ok {
+		if err := closer.Close(); err != nil {
+			klog.Errorf("ERROR: failed to close previous OAuth2 Proxy instance after reload: %v", err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBindAddressDisabled(t *testing.T) {
+	for _, addr := range []string{"", "-"} {
+		if !bindAddressDisabled(addr) {
+			t.Errorf("bindAddressDisabled(%q) = false, want true", addr)
+		}
+	}
+	if bindAddressDisabled("127.0.0.1:4180") {
+		t.Error("bindAddressDisabled(\"127.0.0.1:4180\") = true, want false")
+	}
+}
+
+func TestWatchedFilesAndDirsForPlainFile(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "oauth2-proxy.cfg")
+	if err := os.WriteFile(config, []byte(""), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	names, dirs := watchedFilesAndDirs(reloadConfig{config: config})
+
+	if !names["oauth2-proxy.cfg"] {
+		t.Errorf("names = %v, want to contain the config's basename", names)
+	}
+	if watchedDir, ok := dirs[dir]; !ok || watchedDir {
+		t.Errorf("dirs[%q] = (%v, %v), want (false, true): a file's parent is only watched for its own changes", dir, watchedDir, ok)
+	}
+}
+
+func TestWatchedFilesAndDirsForDirectory(t *testing.T) {
+	confD := t.TempDir()
+
+	_, dirs := watchedFilesAndDirs(reloadConfig{alphaConfigPaths: []string{confD}})
+
+	if watchedDir, ok := dirs[confD]; !ok || !watchedDir {
+		t.Errorf("dirs[%q] = (%v, %v), want (true, true): any change within a given directory should reload", confD, watchedDir, ok)
+	}
+}
+
+func TestCloseStaleProxyNilIsNoop(t *testing.T) {
+	done := make(chan struct{})
+	go func() {
+		closeStaleProxy(nil)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("closeStaleProxy(nil) did not return promptly: it should skip the grace-period sleep entirely when there is nothing to close")
+	}
+}
+
+func TestWatchedFilesAndDirsCombinesConfigAndAlphaConfig(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "oauth2-proxy.cfg")
+	alpha := filepath.Join(dir, "alpha.yaml")
+	for _, path := range []string{config, alpha} {
+		if err := os.WriteFile(path, []byte(""), 0o600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+
+	names, dirs := watchedFilesAndDirs(reloadConfig{config: config, alphaConfigPaths: []string{alpha}})
+
+	if !names["oauth2-proxy.cfg"] || !names["alpha.yaml"] {
+		t.Errorf("names = %v, want both basenames", names)
+	}
+	if _, ok := dirs[dir]; !ok {
+		t.Errorf("dirs = %v, want to contain %q", dirs, dir)
+	}
+}